@@ -1,18 +1,73 @@
 package blerrors
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 	"time"
 )
 
-// Error реализует интерфейс error
+// Error реализует интерфейс error, дописывая сообщение причины, если она задана
 func (e *AppError) Error() string {
-	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+	msg := fmt.Sprintf("[%s] %s", e.Code, e.Message)
+	if e.Cause != nil {
+		msg += fmt.Sprintf(": %s", e.Cause.Error())
+	}
+	return msg
+}
+
+// Unwrap возвращает обернутую причину, позволяя errors.Is/errors.As проходить по цепочке
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is поддерживает errors.Is: две *AppError считаются эквивалентными при совпадении Code
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// MarshalJSON сериализует AppError, добавляя поле cause, когда задана причина
+// (в ProductionMode cause сокращается до голого текста сообщения, чтобы не раскрывать тип ошибки),
+// а также поле trace_legacy в устаревшем плоском формате, когда включен CompatTraceFormat
+func (e *AppError) MarshalJSON() ([]byte, error) {
+	type Alias AppError
+	aux := struct {
+		Cause       string   `json:"cause,omitempty"`
+		TraceLegacy []string `json:"trace_legacy,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+
+	if e.Cause != nil {
+		if ProductionMode {
+			aux.Cause = e.Cause.Error()
+		} else {
+			aux.Cause = fmt.Sprintf("%T: %v", e.Cause, e.Cause)
+		}
+	}
+
+	if CompatTraceFormat && len(e.Trace) > 0 {
+		aux.TraceLegacy = make([]string, len(e.Trace))
+		for i, f := range e.Trace {
+			aux.TraceLegacy[i] = fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Function)
+		}
+	}
+
+	return json.Marshal(aux)
 }
 
-// NewAppError создает новую ошибку приложения
-func NewAppError(code ErrorCode, message, priority, errorCode, errorType, userID string) *AppError {
+// NewAppError создает новую ошибку приложения. Сама по себе она никуда не уведомляется —
+// это делает вызывающий код в точке наблюдения (например, ErrorRecoveryMiddleware или
+// gRPC-интерцептор) через notifyReporters, чтобы каждая ошибка репортилась ровно один раз
+// и с реальным контекстом запроса, а не с context.Background().
+// Priority/ErrorCode/ErrorType/UserID не входят в конструктор - они необязательны
+// и проставляются билдерами IsCritical/WithErrorCode/IsUserError/IsSystemError/WithUserID
+func NewAppError(code ErrorCode, message string) *AppError {
 	now := time.Now()
 	return &AppError{
 		Code:              code,
@@ -21,10 +76,6 @@ func NewAppError(code ErrorCode, message, priority, errorCode, errorType, userID
 		HumanReadableTime: now.Format("2006-01-02 15:04:05"),
 		Trace:             getStackTrace(),
 		Module:            getCurrentModule(),
-		Priority:          priority,
-		ErrorCode:         errorCode,
-		ErrorType:         errorType,
-		UserID:            userID,
 	}
 }
 
@@ -46,6 +97,42 @@ func (e *AppError) WithRequestID(requestID string) *AppError {
 	return e
 }
 
+// WithCause оборачивает исходную ошибку, сохраняя ее в цепочке для Unwrap/Is/As
+func (e *AppError) WithCause(err error) *AppError {
+	e.Cause = err
+	return e
+}
+
+// WithErrorCode устанавливает доменный код ошибки из внешнего каталога (например, "FS001")
+func (e *AppError) WithErrorCode(errorCode string) *AppError {
+	e.ErrorCode = errorCode
+	return e
+}
+
+// WithUserID устанавливает ID пользователя, связанного с ошибкой
+func (e *AppError) WithUserID(userID string) *AppError {
+	e.UserID = userID
+	return e
+}
+
+// IsCritical помечает ошибку как критичную по приоритету
+func (e *AppError) IsCritical() *AppError {
+	e.Priority = "critical"
+	return e
+}
+
+// IsUserError помечает ошибку как вызванную действиями пользователя
+func (e *AppError) IsUserError() *AppError {
+	e.ErrorType = "user"
+	return e
+}
+
+// IsSystemError помечает ошибку как вызванную внутренним сбоем системы
+func (e *AppError) IsSystemError() *AppError {
+	e.ErrorType = "system"
+	return e
+}
+
 // WithoutTrace возвращает ошибку без стека вызовов (для продакшена)
 func (e *AppError) WithoutTrace() *AppError {
 	e.Trace = nil
@@ -53,17 +140,17 @@ func (e *AppError) WithoutTrace() *AppError {
 }
 
 // getStackTrace получает стек вызовов
-func getStackTrace() []string {
+func getStackTrace() []Frame {
 	pc := make([]uintptr, 15)
 	n := runtime.Callers(3, pc) // Пропускаем 3 фрейма (NewAppError, With*, getStackTrace)
 	frames := runtime.CallersFrames(pc[:n])
 
-	var trace []string
+	var trace []Frame
 	for {
 		frame, more := frames.Next()
 		// Фильтруем системные вызовы
 		if !isSystemFrame(frame.Function) {
-			trace = append(trace, fmt.Sprintf("%s:%d %s", frame.File, frame.Line, frame.Function))
+			trace = append(trace, Frame{File: frame.File, Line: frame.Line, Function: frame.Function})
 		}
 		if !more || len(trace) >= 10 { // Ограничиваем глубину стека
 			break
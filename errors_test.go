@@ -1,6 +1,8 @@
 package blerrors
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -116,8 +118,8 @@ func TestGetStackTrace(t *testing.T) {
 
 	// Check that trace contains file information
 	found := false
-	for _, trace := range err.Trace {
-		if strings.Contains(trace, ".go:") {
+	for _, frame := range err.Trace {
+		if strings.Contains(frame.File, ".go") {
 			found = true
 			break
 		}
@@ -165,6 +167,61 @@ func TestErrorCodes(t *testing.T) {
 	}
 }
 
+func TestAppError_WithCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := NewAppError(ErrCodeServiceUnavailable, "Upstream unavailable").WithErrorCode("FS001").IsUserError().WithUserID("user123").WithCause(cause)
+
+	if err.Cause != cause {
+		t.Error("Cause should be the wrapped error")
+	}
+
+	expected := "[SERVICE_UNAVAILABLE] Upstream unavailable: connection refused"
+	if err.Error() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should find the wrapped cause via Unwrap")
+	}
+}
+
+func TestAppError_Is(t *testing.T) {
+	err := NewAppError(ErrCodeNotFound, "Resource not found").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	other := NewAppError(ErrCodeNotFound, "Different message").WithErrorCode("FS001").IsUserError().WithUserID("user456")
+	mismatched := NewAppError(ErrCodeInternal, "Resource not found").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+
+	if !errors.Is(err, other) {
+		t.Error("AppErrors with the same Code should match via errors.Is")
+	}
+
+	if errors.Is(err, mismatched) {
+		t.Error("AppErrors with different Codes should not match via errors.Is")
+	}
+}
+
+func TestAppError_As(t *testing.T) {
+	notFound := &UnitNotFoundError{Unit: "web.service"}
+	err := NewAppError(ErrCodeNotFound, "Unit lookup failed").WithErrorCode("FS001").IsUserError().WithUserID("user123").WithCause(notFound)
+
+	var target *UnitNotFoundError
+	if !errors.As(err, &target) {
+		t.Fatal("errors.As should find the wrapped UnitNotFoundError")
+	}
+
+	if target.Unit != "web.service" {
+		t.Errorf("Expected unit 'web.service', got '%s'", target.Unit)
+	}
+}
+
+// UnitNotFoundError - тестовый типизированный error, имитирующий типизированные ошибки внешних пакетов
+type UnitNotFoundError struct {
+	Unit string
+}
+
+func (e *UnitNotFoundError) Error() string {
+	return fmt.Sprintf("unit %q not found", e.Unit)
+}
+
 func TestTimestampIsRecent(t *testing.T) {
 	before := time.Now().Unix()
 	err := NewAppError(ErrCodeInternal, "Test").WithErrorCode("FS001").IsUserError().WithUserID("user123")
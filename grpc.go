@@ -0,0 +1,167 @@
+package blerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GetGRPCStatus сопоставляет ErrorCode с кодом состояния gRPC
+func GetGRPCStatus(code ErrorCode) codes.Code {
+	switch code {
+	case ErrCodeNotFound:
+		return codes.NotFound
+	case ErrCodeValidation, ErrCodeBadRequest:
+		return codes.InvalidArgument
+	case ErrCodeUnauthorized:
+		return codes.Unauthenticated
+	case ErrCodeForbidden:
+		return codes.PermissionDenied
+	case ErrCodeConflict:
+		return codes.AlreadyExists
+	case ErrCodeTooManyRequests:
+		return codes.ResourceExhausted
+	case ErrCodeServiceUnavailable:
+		return codes.Unavailable
+	case ErrCodeInternal:
+		fallthrough
+	default:
+		return codes.Internal
+	}
+}
+
+// ToGRPCStatus конвертирует AppError в *status.Status, прикладывая ErrorInfo и RequestInfo
+func ToGRPCStatus(err *AppError) *status.Status {
+	st := status.New(GetGRPCStatus(err.Code), err.Message)
+
+	metadata := map[string]string{}
+	if err.Details != nil {
+		metadata["details"] = fmt.Sprintf("%v", err.Details)
+	}
+
+	withDetails, detailErr := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason:   string(err.Code),
+			Domain:   err.Module,
+			Metadata: metadata,
+		},
+		&errdetails.RequestInfo{
+			RequestId: err.RequestID,
+		},
+	)
+	if detailErr != nil {
+		// Детали не критичны для корректности ответа, отдаем статус без них
+		return st
+	}
+	return withDetails
+}
+
+// UnaryServerInterceptor восстанавливает паники в *AppError и конвертирует возвращаемые
+// *AppError в status.Status, зеркалируя ErrorRecoveryMiddleware/RequestIDMiddleware для gRPC
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx, requestID := ensureGRPCRequestID(ctx)
+
+		defer func() {
+			if r := recover(); r != nil {
+				appErr := NewAppError(ErrCodeInternal, "Internal server error").
+					WithModule("grpc").
+					WithRequestID(requestID).
+					WithDetails(fmt.Sprintf("panic recovered: %v", r))
+				notifyReporters(ctx, appErr)
+				err = ToGRPCStatus(appErr).Err()
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		var appErr *AppError
+		if errors.As(err, &appErr) {
+			appErr.WithRequestID(requestID)
+			notifyReporters(ctx, appErr)
+			return resp, ToGRPCStatus(appErr).Err()
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor - потоковый аналог UnaryServerInterceptor
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, requestID := ensureGRPCRequestID(ss.Context())
+		wrapped := &requestIDServerStream{ServerStream: ss, ctx: ctx}
+
+		defer func() {
+			if r := recover(); r != nil {
+				appErr := NewAppError(ErrCodeInternal, "Internal server error").
+					WithModule("grpc").
+					WithRequestID(requestID).
+					WithDetails(fmt.Sprintf("panic recovered: %v", r))
+				notifyReporters(ctx, appErr)
+				err = ToGRPCStatus(appErr).Err()
+			}
+		}()
+
+		err = handler(srv, wrapped)
+		if err == nil {
+			return nil
+		}
+
+		var appErr *AppError
+		if errors.As(err, &appErr) {
+			appErr.WithRequestID(requestID)
+			notifyReporters(ctx, appErr)
+			return ToGRPCStatus(appErr).Err()
+		}
+		return err
+	}
+}
+
+// requestIDServerStream оборачивает grpc.ServerStream, подменяя Context() на версию с request ID
+type requestIDServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// ensureGRPCRequestID возвращает request ID, зеркалируя RequestIDMiddleware: сперва контекст,
+// затем входящие метаданные x-request-id/x-correlation-id, и только потом генерирует новый
+func ensureGRPCRequestID(ctx context.Context) (context.Context, string) {
+	if id := GetRequestID(ctx); id != "" {
+		return ctx, id
+	}
+
+	id := requestIDFromIncomingMetadata(ctx)
+	if !isValidRequestID(id) {
+		id = newRequestID()
+	}
+	return context.WithValue(ctx, requestIDKey, id), id
+}
+
+// requestIDFromIncomingMetadata читает x-request-id/x-correlation-id из входящих gRPC
+// метаданных (grpc-gateway/Twirp lower-case их имена заголовков)
+func requestIDFromIncomingMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get("x-request-id"); len(vals) > 0 {
+		return vals[0]
+	}
+	if vals := md.Get("x-correlation-id"); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
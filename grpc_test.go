@@ -0,0 +1,82 @@
+package blerrors
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGetGRPCStatus(t *testing.T) {
+	testCases := []struct {
+		code     ErrorCode
+		expected codes.Code
+	}{
+		{ErrCodeNotFound, codes.NotFound},
+		{ErrCodeValidation, codes.InvalidArgument},
+		{ErrCodeBadRequest, codes.InvalidArgument},
+		{ErrCodeUnauthorized, codes.Unauthenticated},
+		{ErrCodeForbidden, codes.PermissionDenied},
+		{ErrCodeConflict, codes.AlreadyExists},
+		{ErrCodeTooManyRequests, codes.ResourceExhausted},
+		{ErrCodeServiceUnavailable, codes.Unavailable},
+		{ErrCodeInternal, codes.Internal},
+		{ErrorCode("UNKNOWN"), codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		if got := GetGRPCStatus(tc.code); got != tc.expected {
+			t.Errorf("For code %s, expected %s, got %s", tc.code, tc.expected, got)
+		}
+	}
+}
+
+func TestToGRPCStatus(t *testing.T) {
+	err := NewAppError(ErrCodeNotFound, "Resource not found").WithErrorCode("FS001").IsUserError().WithUserID("user123").
+		WithModule("catalog").WithRequestID("req-123")
+
+	st := ToGRPCStatus(err)
+
+	if st.Code() != codes.NotFound {
+		t.Errorf("Expected code %s, got %s", codes.NotFound, st.Code())
+	}
+
+	if st.Message() != "Resource not found" {
+		t.Errorf("Expected message 'Resource not found', got '%s'", st.Message())
+	}
+
+	if len(st.Details()) != 2 {
+		t.Errorf("Expected 2 detail messages, got %d", len(st.Details()))
+	}
+}
+
+func TestEnsureGRPCRequestID_HonorsIncomingMetadata(t *testing.T) {
+	md := metadata.Pairs("x-request-id", "req-from-metadata")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, requestID := ensureGRPCRequestID(ctx)
+
+	if requestID != "req-from-metadata" {
+		t.Errorf("Expected request ID from incoming metadata, got '%s'", requestID)
+	}
+}
+
+func TestEnsureGRPCRequestID_FallsBackToCorrelationID(t *testing.T) {
+	md := metadata.Pairs("x-correlation-id", "corr-from-metadata")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, requestID := ensureGRPCRequestID(ctx)
+
+	if requestID != "corr-from-metadata" {
+		t.Errorf("Expected request ID from x-correlation-id metadata, got '%s'", requestID)
+	}
+}
+
+func TestEnsureGRPCRequestID_GeneratesWhenMetadataMissing(t *testing.T) {
+	_, requestID := ensureGRPCRequestID(context.Background())
+
+	if requestID == "" {
+		t.Error("Expected a generated request ID when no metadata is present")
+	}
+}
@@ -2,15 +2,39 @@ package blerrors
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// WriteErrorResponse отправляет JSON ответ с ошибкой
+// WriteErrorResponse отправляет JSON ответ с ошибкой и уведомляет зарегистрированные
+// Reporter'ы - это точка наблюдения для ошибок, покидающих HTTP-слой без запроса под рукой
+// (как context.Background()). ErrorRecoveryMiddleware его не вызывает: он обращается
+// напрямую к writeErrorResponseCtx, передавая контекст запроса.
+// Если у err задана Cause, она попадает в ответ как поле error.cause
+// (см. AppError.MarshalJSON и ProductionMode).
 func WriteErrorResponse(w http.ResponseWriter, err *AppError) {
+	writeErrorResponseCtx(context.Background(), w, err)
+}
+
+// writeErrorResponseCtx делает всю работу WriteErrorResponse, уведомляя Reporter'ы
+// с переданным ctx, чтобы вызывающий код с реальным контекстом запроса (middleware)
+// не дублировал notifyReporters и не терял его в context.Background()
+func writeErrorResponseCtx(ctx context.Context, w http.ResponseWriter, err *AppError) {
+	notifyReporters(ctx, err)
+	writeJSONErrorResponse(w, err)
+}
+
+// writeJSONErrorResponse пишет JSON-конверт с ошибкой без уведомления Reporter'ов
+// (вызывающий код уведомляет сам, до применения ProductionMode-редактирования)
+func writeJSONErrorResponse(w http.ResponseWriter, err *AppError) {
 	w.Header().Set("Content-Type", "application/json")
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds(err.RetryAfter)))
+	}
 	w.WriteHeader(getHTTPStatusCode(err.Code))
 
 	response := ErrorResponse{
@@ -37,6 +61,13 @@ func WriteSuccessResponse(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// retryAfterSeconds округляет RetryAfter вверх до целого числа секунд, чтобы положительная
+// задержка никогда не превращалась в заголовок "Retry-After: 0" (например, 500ms) и
+// не занижалась (например, 1.9s не должно стать "1")
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
 // getHTTPStatusCode возвращает HTTP статус код для кода ошибки
 func getHTTPStatusCode(code ErrorCode) int {
 	switch code {
@@ -71,31 +102,241 @@ func ErrorRecoveryMiddleware(next http.Handler) http.Handler {
 					WithDetails(fmt.Sprintf("Panic recovered: %v", err)).
 					WithModule("middleware")
 
-				WriteErrorResponse(w, appErr)
+				writeErrorResponseCtx(r.Context(), w, appErr)
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequestIDMiddleware добавляет request ID к контексту
+// ctxKey - непубличный тип ключа контекста, чтобы не коллизировать с ключами других пакетов
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	traceIDKey
+	spanIDKey
+)
+
+// legacyRequestIDContextKey - ключ контекста в виде голой строки, под которым request ID
+// хранился раньше; сохранен для обратной совместимости на время миграции потребителей
+const legacyRequestIDContextKey = "request_id"
+
+// RequestIDMiddleware добавляет request ID к контексту: переиспользует входящий
+// X-Request-Id/X-Correlation-Id, если он валиден, иначе генерирует UUIDv4.
+// Также разбирает W3C traceparent и возвращает выбранный ID в ответе.
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Генерируем простой request ID (в продакшене используйте UUID)
-		requestID := fmt.Sprintf("%d", time.Now().UnixNano())
+		requestID := r.Header.Get("X-Request-Id")
+		if requestID == "" {
+			requestID = r.Header.Get("X-Correlation-Id")
+		}
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+
+		if traceID, spanID, ok := parseTraceParent(r.Header.Get("traceparent")); ok {
+			ctx = context.WithValue(ctx, traceIDKey, traceID)
+			ctx = context.WithValue(ctx, spanIDKey, spanID)
+		}
 
-		// Добавляем в контекст
-		ctx := context.WithValue(r.Context(), "request_id", requestID)
+		w.Header().Set("X-Request-Id", requestID)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
-// GetRequestID извлекает request ID из контекста
+// GetRequestID извлекает request ID из контекста, проверяя как текущий типизированный
+// ключ, так и устаревший строковый ключ для обратной совместимости
 func GetRequestID(ctx context.Context) string {
-	if requestID, ok := ctx.Value("request_id").(string); ok {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	if requestID, ok := ctx.Value(legacyRequestIDContextKey).(string); ok {
 		return requestID
 	}
 	return ""
 }
+
+// GetTraceID извлекает W3C trace ID из контекста, если он был передан через traceparent
+func GetTraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey).(string)
+	return traceID
+}
+
+// GetSpanID извлекает W3C span ID из контекста, если он был передан через traceparent
+func GetSpanID(ctx context.Context) string {
+	spanID, _ := ctx.Value(spanIDKey).(string)
+	return spanID
+}
+
+// newRequestID генерирует UUIDv4 через crypto/rand
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand не должен отказывать на поддерживаемых платформах,
+		// но на случай отказа не оставляем запрос без идентификатора
+		return fmt.Sprintf("fallback-%x", b)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // версия 4
+	b[8] = (b[8] & 0x3f) | 0x80 // вариант RFC 4122
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// isValidRequestID проверяет, что входящий request ID разумен для повторного использования:
+// непустой, без управляющих символов и в разумных пределах длины
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceParent разбирает заголовок W3C traceparent вида
+// "00-<32 hex trace id>-<16 hex span id>-<flags>", отклоняя невалидные по спецификации
+// значения: нехекс-символы и зарезервированные all-zero trace-id/span-id
+func parseTraceParent(traceparent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 {
+		return "", "", false
+	}
+
+	traceID, spanID = parts[1], parts[2]
+	if len(traceID) != 32 || len(spanID) != 16 {
+		return "", "", false
+	}
+	if !isLowerHex(traceID) || !isLowerHex(spanID) {
+		return "", "", false
+	}
+	if isAllZero(traceID) || isAllZero(spanID) {
+		return "", "", false
+	}
+
+	return traceID, spanID, true
+}
+
+// isLowerHex проверяет, что строка состоит только из символов нижнего регистра 0-9a-f,
+// как того требует W3C Trace Context для trace-id/span-id
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAllZero сообщает, состоит ли строка сплошь из нулей (спецификация резервирует
+// all-zero trace-id/span-id как явно невалидные значения)
+func isAllZero(s string) bool {
+	for _, r := range s {
+		if r != '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// Chain компонует middleware в один обработчик, применяя их в переданном порядке
+// (первый в списке выполняется первым)
+func Chain(mw ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(final http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			final = mw[i](final)
+		}
+		return final
+	}
+}
+
+// RecommendedChain - рекомендуемая цепочка middleware для новых сервисов:
+// восстановление от паник, затем проставление request ID
+func RecommendedChain() func(http.Handler) http.Handler {
+	return Chain(ErrorRecoveryMiddleware, RequestIDMiddleware)
+}
+
+// Handler инкапсулирует Config и пишет ответы об ошибках в соответствии с ней
+type Handler struct {
+	cfg Config
+}
+
+// NewHandler создает Handler с заданной конфигурацией
+func NewHandler(cfg Config) *Handler {
+	return &Handler{cfg: cfg}
+}
+
+// WriteErrorResponse пишет ответ об ошибке, выбирая между JSON-конвертом по умолчанию
+// и RFC 7807 application/problem+json в зависимости от заголовка Accept запроса,
+// и применяя ProductionMode/StatusTextFallback из Config. Reporter'ы уведомляются исходным
+// err до любого редактирования; само редактирование выполняется на его копии, чтобы
+// Reporter, удерживающий указатель (например, асинхронный батчинг в Sentry-транспорте),
+// не увидел урезанные Trace/Details позже.
+func (h *Handler) WriteErrorResponse(w http.ResponseWriter, r *http.Request, err *AppError) {
+	notifyReporters(r.Context(), err)
+
+	respErr := new(AppError)
+	*respErr = *err
+
+	if h.cfg.StatusTextFallback && respErr.Message == "" {
+		respErr.Message = http.StatusText(getHTTPStatusCode(respErr.Code))
+	}
+
+	if h.cfg.ProductionMode && getHTTPStatusCode(respErr.Code) >= http.StatusInternalServerError {
+		// Снимаем Cause вместе с Trace/Details: иначе MarshalJSON редактирует cause только
+		// по пакетному ProductionMode, который Config не устанавливает, и утечет "%T: %v"
+		// с типом обернутой ошибки в обход Config.ProductionMode
+		respErr.WithoutTrace().WithDetails(nil)
+		respErr.Cause = nil
+	}
+
+	err = respErr
+
+	if wantsProblemJSON(r) {
+		h.writeProblemJSON(w, err)
+		return
+	}
+
+	writeJSONErrorResponse(w, err)
+}
+
+// wantsProblemJSON сообщает, запросил ли клиент application/problem+json через Accept
+func wantsProblemJSON(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/problem+json")
+}
+
+// writeProblemJSON пишет ответ об ошибке в формате RFC 7807 (Reporter'ы уже уведомлены
+// вызывающим Handler.WriteErrorResponse, до ProductionMode-редактирования)
+func (h *Handler) writeProblemJSON(w http.ResponseWriter, err *AppError) {
+	status := getHTTPStatusCode(err.Code)
+
+	problem := ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Message,
+		Code:      err.Code,
+		RequestID: err.RequestID,
+		Trace:     err.Trace,
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds(err.RetryAfter)))
+	}
+	w.WriteHeader(status)
+
+	if encodeErr := json.NewEncoder(w).Encode(problem); encodeErr != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestWriteErrorResponse(t *testing.T) {
@@ -46,6 +48,36 @@ func TestWriteErrorResponse(t *testing.T) {
 	}
 }
 
+func TestRetryAfterSeconds_RoundsUp(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected int
+	}{
+		{500 * time.Millisecond, 1},
+		{1900 * time.Millisecond, 2},
+		{1 * time.Second, 1},
+		{0, 0},
+	}
+
+	for _, tc := range testCases {
+		if got := retryAfterSeconds(tc.duration); got != tc.expected {
+			t.Errorf("retryAfterSeconds(%s) = %d, expected %d", tc.duration, got, tc.expected)
+		}
+	}
+}
+
+func TestWriteErrorResponse_RetryAfterHeaderRoundsUp(t *testing.T) {
+	err := NewAppError(ErrCodeTooManyRequests, "slow down")
+	err.RetryAfter = 500 * time.Millisecond
+
+	w := httptest.NewRecorder()
+	WriteErrorResponse(w, err)
+
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After '1' for a 500ms delay, got '%s'", got)
+	}
+}
+
 func TestWriteSuccessResponse(t *testing.T) {
 	data := map[string]interface{}{
 		"user_id": 123,
@@ -170,9 +202,9 @@ func TestRequestIDMiddleware(t *testing.T) {
 			t.Error("Request ID should not be empty")
 		}
 
-		// Check that it's a reasonable timestamp-based ID
-		if len(requestID) < 10 {
-			t.Errorf("Request ID seems too short: %s", requestID)
+		// Should look like a UUIDv4
+		if len(requestID) != 36 {
+			t.Errorf("Request ID should be a UUIDv4, got: %s", requestID)
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -190,6 +222,343 @@ func TestRequestIDMiddleware(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
+
+	if w.Header().Get("X-Request-Id") == "" {
+		t.Error("X-Request-Id should be echoed back in the response")
+	}
+}
+
+func TestRequestIDMiddleware_HonorsIncomingHeader(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := GetRequestID(r.Context()); got != "upstream-id-123" {
+			t.Errorf("Expected request ID 'upstream-id-123', got '%s'", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RequestIDMiddleware(testHandler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-Id", "upstream-id-123")
+
+	middleware.ServeHTTP(w, req)
+
+	if w.Header().Get("X-Request-Id") != "upstream-id-123" {
+		t.Errorf("Expected echoed header 'upstream-id-123', got '%s'", w.Header().Get("X-Request-Id"))
+	}
+}
+
+func TestRequestIDMiddleware_ParsesTraceParent(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const spanID = "00f067aa0ba902b7"
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := GetTraceID(r.Context()); got != traceID {
+			t.Errorf("Expected trace ID '%s', got '%s'", traceID, got)
+		}
+		if got := GetSpanID(r.Context()); got != spanID {
+			t.Errorf("Expected span ID '%s', got '%s'", spanID, got)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RequestIDMiddleware(testHandler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+
+	middleware.ServeHTTP(w, req)
+}
+
+func TestRequestIDMiddleware_RejectsInvalidTraceParent(t *testing.T) {
+	cases := []struct {
+		name        string
+		traceparent string
+	}{
+		{"non-hex trace id", "00-zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz-00f067aa0ba902b7-01"},
+		{"non-hex span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-zzzzzzzzzzzzzzzz-01"},
+		{"all-zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{"all-zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := GetTraceID(r.Context()); got != "" {
+					t.Errorf("Expected no trace ID for invalid traceparent, got '%s'", got)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			middleware := RequestIDMiddleware(testHandler)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("traceparent", tc.traceparent)
+
+			middleware.ServeHTTP(w, req)
+		})
+	}
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	if isValidRequestID("") {
+		t.Error("Empty string should be invalid")
+	}
+	if isValidRequestID("has\ncontrol\tchars") {
+		t.Error("Strings with control characters should be invalid")
+	}
+	if !isValidRequestID("req-12345") {
+		t.Error("A normal ID should be valid")
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+
+	mkMiddleware := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(mkMiddleware("first"), mkMiddleware("second"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	handler.ServeHTTP(w, req)
+
+	expected := []string{"first", "second", "handler"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected order %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Expected order %v, got %v", expected, order)
+			break
+		}
+	}
+}
+
+func TestHandler_WriteErrorResponse_JSON(t *testing.T) {
+	h := NewHandler(Config{})
+	err := NewAppError(ErrCodeNotFound, "Resource not found").WithModule("test-service")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected application/json, got %s", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandler_WriteErrorResponse_ProblemJSON(t *testing.T) {
+	h := NewHandler(Config{})
+	err := NewAppError(ErrCodeNotFound, "Resource not found").WithModule("test-service").WithRequestID("req-1")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	h.WriteErrorResponse(w, req, err)
+
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Expected application/problem+json, got %s", w.Header().Get("Content-Type"))
+	}
+
+	var problem ProblemDetails
+	if decodeErr := json.NewDecoder(w.Body).Decode(&problem); decodeErr != nil {
+		t.Fatalf("Failed to decode problem+json response: %v", decodeErr)
+	}
+
+	if problem.Status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, problem.Status)
+	}
+	if problem.Detail != "Resource not found" {
+		t.Errorf("Expected detail 'Resource not found', got '%s'", problem.Detail)
+	}
+	if problem.RequestID != "req-1" {
+		t.Errorf("Expected request_id 'req-1', got '%s'", problem.RequestID)
+	}
+}
+
+func TestWriteErrorResponse_NotifiesReporters(t *testing.T) {
+	defer SetReporter(nil)
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	err := NewAppError(ErrCodeNotFound, "Resource not found")
+	w := httptest.NewRecorder()
+	WriteErrorResponse(w, err)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected WriteErrorResponse to notify reporters exactly once, got %d", len(rec.calls))
+	}
+}
+
+func TestHandler_WriteErrorResponse_NotifiesReportersOnce(t *testing.T) {
+	defer SetReporter(nil)
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	h := NewHandler(Config{})
+	err := NewAppError(ErrCodeNotFound, "Resource not found")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected Handler.WriteErrorResponse to notify reporters exactly once, got %d", len(rec.calls))
+	}
+}
+
+func TestHandler_WriteErrorResponse_ProblemJSON_NotifiesReportersOnce(t *testing.T) {
+	defer SetReporter(nil)
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	h := NewHandler(Config{})
+	err := NewAppError(ErrCodeNotFound, "Resource not found")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	h.WriteErrorResponse(w, req, err)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected problem+json path to notify reporters exactly once, got %d", len(rec.calls))
+	}
+}
+
+func TestHandler_WriteErrorResponse_ReportsBeforeProductionModeStripsDetails(t *testing.T) {
+	defer SetReporter(nil)
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	h := NewHandler(Config{ProductionMode: true})
+	err := NewAppError(ErrCodeInternal, "boom").WithDetails("sensitive internals")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected 1 reported error, got %d", len(rec.calls))
+	}
+	if rec.calls[0].Details == nil || rec.calls[0].Trace == nil {
+		t.Error("Reporter should receive the error before ProductionMode strips Trace/Details, not after")
+	}
+}
+
+func TestErrorRecoveryMiddleware_NotifiesReportersOnce(t *testing.T) {
+	defer SetReporter(nil)
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	middleware := ErrorRecoveryMiddleware(panicking)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	middleware.ServeHTTP(w, req)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected recovered panic to notify reporters exactly once, got %d", len(rec.calls))
+	}
+}
+
+func TestHandler_WriteErrorResponse_ProductionModeStripsTrace(t *testing.T) {
+	h := NewHandler(Config{ProductionMode: true})
+	err := NewAppError(ErrCodeInternal, "boom").WithDetails("sensitive internals")
+
+	if len(err.Trace) == 0 {
+		t.Fatal("Expected a populated trace before stripping")
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	var response ErrorResponse
+	if decodeErr := json.NewDecoder(w.Body).Decode(&response); decodeErr != nil {
+		t.Fatalf("Failed to decode response: %v", decodeErr)
+	}
+
+	if response.Error.Trace != nil {
+		t.Error("ProductionMode should strip Trace for 5xx errors in the response")
+	}
+	if response.Error.Details != nil {
+		t.Error("ProductionMode should strip Details for 5xx errors in the response")
+	}
+
+	// The caller's original error must stay intact so an async Reporter holding
+	// the same pointer still observes the full, unstripped error.
+	if err.Trace == nil {
+		t.Error("ProductionMode stripping must not mutate the caller's original error")
+	}
+	if err.Details == nil {
+		t.Error("ProductionMode stripping must not mutate the caller's original error")
+	}
+}
+
+func TestHandler_WriteErrorResponse_ProductionModeStripsCauseRegardlessOfGlobalFlag(t *testing.T) {
+	// The package-level ProductionMode is read only by AppError.MarshalJSON;
+	// Config.ProductionMode must redact cause on its own, without relying on it.
+	ProductionMode = false
+	defer func() { ProductionMode = false }()
+
+	h := NewHandler(Config{ProductionMode: true})
+	err := NewAppError(ErrCodeInternal, "boom").WithCause(errors.New("db: connection refused"))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	body := w.Body.String()
+	if strings.Contains(body, "cause") {
+		t.Errorf("Config.ProductionMode should strip cause from the response, got body: %s", body)
+	}
+	if strings.Contains(body, "connection refused") {
+		t.Errorf("Config.ProductionMode should not leak the cause message, got body: %s", body)
+	}
+}
+
+func TestHandler_WriteErrorResponse_StatusTextFallback(t *testing.T) {
+	h := NewHandler(Config{StatusTextFallback: true})
+	err := NewAppError(ErrCodeConflict, "")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	h.WriteErrorResponse(w, req, err)
+
+	var response ErrorResponse
+	if decodeErr := json.NewDecoder(w.Body).Decode(&response); decodeErr != nil {
+		t.Fatalf("Failed to decode response: %v", decodeErr)
+	}
+
+	if response.Error.Message != http.StatusText(http.StatusConflict) {
+		t.Errorf("Expected message '%s', got '%s'", http.StatusText(http.StatusConflict), response.Error.Message)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := newRequestID()
+	if len(id) != 36 {
+		t.Errorf("Expected a UUIDv4-shaped ID, got: %s", id)
+	}
+	if id == newRequestID() {
+		t.Error("Two generated IDs should not collide")
+	}
 }
 
 func TestGetRequestID(t *testing.T) {
@@ -0,0 +1,107 @@
+package blerrors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Reporter получает уведомления об ошибках для целей наблюдаемости
+// (логирование, отправка во внешние системы мониторинга и т.п.). Уведомления
+// рассылают WriteErrorResponse, Handler.WriteErrorResponse, ErrorRecoveryMiddleware
+// и gRPC-интерцепторы; создание ошибки через NewAppError reporter'ов не вызывает.
+type Reporter interface {
+	Report(ctx context.Context, err *AppError)
+}
+
+var (
+	reportersMu sync.Mutex
+	reporters   []Reporter
+)
+
+// RegisterReporter добавляет reporter к списку получателей уведомлений об ошибках
+func RegisterReporter(r Reporter) {
+	reportersMu.Lock()
+	defer reportersMu.Unlock()
+	reporters = append(reporters, r)
+}
+
+// SetReporter заменяет весь список репортеров единственным переданным
+// (удобно для тестов и для приложений с одним получателем)
+func SetReporter(r Reporter) {
+	reportersMu.Lock()
+	defer reportersMu.Unlock()
+	if r == nil {
+		reporters = nil
+		return
+	}
+	reporters = []Reporter{r}
+}
+
+// notifyReporters уведомляет все зарегистрированные reporter'ы об ошибке
+func notifyReporters(ctx context.Context, err *AppError) {
+	reportersMu.Lock()
+	rs := make([]Reporter, len(reporters))
+	copy(rs, reporters)
+	reportersMu.Unlock()
+
+	for _, r := range rs {
+		r.Report(ctx, err)
+	}
+}
+
+// SlogReporter - адаптер Reporter, отправляющий ошибки в log/slog,
+// раскладывая каждый кадр стека в структурированные атрибуты (file, line, func)
+type SlogReporter struct {
+	Logger *slog.Logger
+}
+
+// NewSlogReporter создает SlogReporter поверх переданного логгера
+// (если logger == nil, используется slog.Default())
+func NewSlogReporter(logger *slog.Logger) *SlogReporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogReporter{Logger: logger}
+}
+
+// Report реализует Reporter для SlogReporter
+func (s *SlogReporter) Report(ctx context.Context, err *AppError) {
+	attrs := []any{
+		slog.String("code", string(err.Code)),
+		slog.String("module", err.Module),
+		slog.String("request_id", err.RequestID),
+	}
+
+	for i, frame := range err.Trace {
+		attrs = append(attrs, slog.Group(fmt.Sprintf("frame_%d", i),
+			slog.String("file", frame.File),
+			slog.Int("line", frame.Line),
+			slog.String("func", frame.Function),
+		))
+	}
+
+	s.Logger.ErrorContext(ctx, err.Message, attrs...)
+}
+
+// SentryReporter - адаптер Reporter, пересылающий ошибки во внешний транспорт
+// в стиле Sentry/GlitchTip через пользовательскую функцию
+type SentryReporter struct {
+	Transport func(*AppError) error
+}
+
+// NewSentryReporter создает SentryReporter с заданной функцией отправки
+func NewSentryReporter(transport func(*AppError) error) *SentryReporter {
+	return &SentryReporter{Transport: transport}
+}
+
+// Report реализует Reporter для SentryReporter
+func (s *SentryReporter) Report(ctx context.Context, err *AppError) {
+	if s.Transport == nil {
+		return
+	}
+	if sendErr := s.Transport(err); sendErr != nil {
+		slog.ErrorContext(ctx, "failed to send error to sentry-compatible transport", "error", sendErr)
+	}
+}
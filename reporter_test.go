@@ -0,0 +1,76 @@
+package blerrors
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingReporter struct {
+	calls []*AppError
+}
+
+func (r *recordingReporter) Report(ctx context.Context, err *AppError) {
+	r.calls = append(r.calls, err)
+}
+
+func TestSetReporter(t *testing.T) {
+	defer SetReporter(nil)
+
+	rec := &recordingReporter{}
+	SetReporter(rec)
+
+	err := NewAppError(ErrCodeInternal, "boom").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+
+	// NewAppError itself must not notify reporters - only the observation point
+	// (middleware/interceptor) does, via notifyReporters, to avoid double-reporting.
+	if len(rec.calls) != 0 {
+		t.Fatalf("NewAppError should not report on its own, got %d calls", len(rec.calls))
+	}
+
+	notifyReporters(context.Background(), err)
+
+	if len(rec.calls) != 1 {
+		t.Fatalf("Expected 1 reported error, got %d", len(rec.calls))
+	}
+
+	if rec.calls[0] != err {
+		t.Error("Reporter should receive the error passed to notifyReporters")
+	}
+}
+
+func TestRegisterReporter(t *testing.T) {
+	defer SetReporter(nil)
+
+	first := &recordingReporter{}
+	second := &recordingReporter{}
+	RegisterReporter(first)
+	RegisterReporter(second)
+
+	err := NewAppError(ErrCodeInternal, "boom").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	notifyReporters(context.Background(), err)
+
+	if len(first.calls) != 1 || len(second.calls) != 1 {
+		t.Error("All registered reporters should be notified")
+	}
+}
+
+func TestSentryReporter_NilTransport(t *testing.T) {
+	r := NewSentryReporter(nil)
+	// Should not panic when no transport is configured
+	r.Report(context.Background(), NewAppError(ErrCodeInternal, "boom").WithErrorCode("FS001").IsUserError().WithUserID("user123"))
+}
+
+func TestSentryReporter_Transport(t *testing.T) {
+	var received *AppError
+	r := NewSentryReporter(func(err *AppError) error {
+		received = err
+		return nil
+	})
+
+	err := NewAppError(ErrCodeInternal, "boom").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	r.Report(context.Background(), err)
+
+	if received != err {
+		t.Error("Transport should receive the reported error")
+	}
+}
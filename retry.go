@@ -0,0 +1,111 @@
+package blerrors
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy описывает параметры экспоненциального backoff с джиттером для Do
+type RetryPolicy struct {
+	Base        time.Duration // Задержка перед первым повтором
+	Max         time.Duration // Верхняя граница задержки между повторами
+	Multiplier  float64       // Во сколько раз растет задержка на каждом шаге
+	MaxAttempts int           // Максимальное число попыток, включая первую
+}
+
+// DefaultRetryPolicy - разумные значения по умолчанию для Do
+var DefaultRetryPolicy = RetryPolicy{
+	Base:        100 * time.Millisecond,
+	Max:         10 * time.Second,
+	Multiplier:  2.0,
+	MaxAttempts: 5,
+}
+
+// Retryable сообщает, имеет ли смысл повторить операцию, завершившуюся ошибкой err
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		switch appErr.Code {
+		case ErrCodeTooManyRequests, ErrCodeServiceUnavailable:
+			return true
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	return false
+}
+
+// RetryAfter возвращает рекомендуемую задержку перед повтором, указанную в ошибке
+func RetryAfter(err *AppError) time.Duration {
+	if err == nil {
+		return 0
+	}
+	return err.RetryAfter
+}
+
+// Do выполняет fn, повторяя ее при повторяемых ошибках согласно policy:
+// экспоненциальный backoff с джиттером, остановка на неповторяемых ошибках,
+// уважение ctx.Done() и возврат последней *AppError с Details["attempts"]
+func Do(ctx context.Context, fn func() error, policy RetryPolicy) error {
+	var lastErr error
+	delay := policy.Base
+	attempts := 0
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		attempts = attempt
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+
+		if !Retryable(lastErr) || attempt == policy.MaxAttempts {
+			break
+		}
+
+		wait := delay
+		if jitter := time.Duration(rand.Int63n(int64(delay) + 1)); jitter > 0 {
+			wait = delay/2 + jitter/2
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if delay > policy.Max {
+			delay = policy.Max
+		}
+	}
+
+	var appErr *AppError
+	if errors.As(lastErr, &appErr) {
+		details := map[string]interface{}{"attempts": attempts}
+		if existing, ok := appErr.Details.(map[string]interface{}); ok {
+			for k, v := range existing {
+				details[k] = v
+			}
+		}
+		appErr.WithDetails(details)
+		return appErr
+	}
+
+	return lastErr
+}
@@ -0,0 +1,131 @@
+package blerrors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type fakeTimeoutError struct{}
+
+func (fakeTimeoutError) Error() string   { return "timeout" }
+func (fakeTimeoutError) Timeout() bool   { return true }
+func (fakeTimeoutError) Temporary() bool { return true }
+
+func TestRetryable(t *testing.T) {
+	var timeoutErr net.Error = fakeTimeoutError{}
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"too many requests", NewAppError(ErrCodeTooManyRequests, "slow down").WithErrorCode("FS001").IsUserError().WithUserID("user123"), true},
+		{"service unavailable", NewAppError(ErrCodeServiceUnavailable, "down").WithErrorCode("FS001").IsUserError().WithUserID("user123"), true},
+		{"not found is not retryable", NewAppError(ErrCodeNotFound, "missing").WithErrorCode("FS001").IsUserError().WithUserID("user123"), false},
+		{"timeout net.Error", timeoutErr, true},
+		{"econnreset", syscall.ECONNRESET, true},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Retryable(tc.err); got != tc.expected {
+				t.Errorf("Retryable(%v) = %v, want %v", tc.err, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := NewAppError(ErrCodeTooManyRequests, "slow down").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	err.RetryAfter = 5 * time.Second
+
+	if got := RetryAfter(err); got != 5*time.Second {
+		t.Errorf("Expected 5s, got %v", got)
+	}
+
+	if got := RetryAfter(nil); got != 0 {
+		t.Errorf("Expected 0 for nil error, got %v", got)
+	}
+}
+
+func TestDo_SucceedsAfterRetries(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return NewAppError(ErrCodeServiceUnavailable, "down").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+		}
+		return nil
+	}, RetryPolicy{Base: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2, MaxAttempts: 5})
+
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDo_StopsOnNonRetryable(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		return NewAppError(ErrCodeNotFound, "missing").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	}, RetryPolicy{Base: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2, MaxAttempts: 5})
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt for a non-retryable error, got %d", attempts)
+	}
+}
+
+func TestDo_ExhaustsAttemptsAndReportsCount(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		return NewAppError(ErrCodeServiceUnavailable, "down").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	}, RetryPolicy{Base: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2, MaxAttempts: 3})
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("Expected *AppError, got %T", err)
+	}
+
+	details, ok := appErr.Details.(map[string]interface{})
+	if !ok {
+		t.Fatal("Details should be a map")
+	}
+	if details["attempts"] != 3 {
+		t.Errorf("Expected attempts 3, got %v", details["attempts"])
+	}
+}
+
+func TestDo_RespectsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := Do(ctx, func() error {
+		attempts++
+		return NewAppError(ErrCodeServiceUnavailable, "down").WithErrorCode("FS001").IsUserError().WithUserID("user123")
+	}, RetryPolicy{Base: 50 * time.Millisecond, Max: time.Second, Multiplier: 2, MaxAttempts: 5})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt before the context was observed as done, got %d", attempts)
+	}
+}
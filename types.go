@@ -1,5 +1,7 @@
 package blerrors
 
+import "time"
+
 // ErrorCode - уникальный код ошибки
 type ErrorCode string
 
@@ -18,21 +20,61 @@ const (
 
 // AppError - унифицированная структура ошибки
 type AppError struct {
-	Code      ErrorCode   `json:"code"`                 // Уникальный код ошибки
-	Message   string      `json:"message"`              // Текстовое описание
-	Timestamp int64       `json:"timestamp"`            // Время в Unix timestamp
-	Module    string      `json:"module,omitempty"`     // Модуль/сервис где произошла ошибка
-	Trace     []string    `json:"trace,omitempty"`      // Стек вызовов
-	Details   interface{} `json:"details,omitempty"`    // Дополнительные детали
-	RequestID string      `json:"request_id,omitempty"` // ID запроса для трекинга
+	Code              ErrorCode     `json:"code"`                          // Уникальный код ошибки
+	Message           string        `json:"message"`                       // Текстовое описание
+	Timestamp         int64         `json:"timestamp"`                     // Время в Unix timestamp
+	HumanReadableTime string        `json:"human_readable_time,omitempty"` // Время в читаемом формате
+	Module            string        `json:"module,omitempty"`              // Модуль/сервис где произошла ошибка
+	Trace             []Frame       `json:"trace,omitempty"`               // Стек вызовов
+	Details           interface{}   `json:"details,omitempty"`             // Дополнительные детали
+	RequestID         string        `json:"request_id,omitempty"`          // ID запроса для трекинга
+	Cause             error         `json:"-"`                             // Исходная ошибка, которую оборачивает AppError
+	RetryAfter        time.Duration `json:"-"`                             // Рекомендуемая задержка перед повтором запроса
+	Priority          string        `json:"priority,omitempty"`            // Приоритет ошибки (например, "critical"), см. IsCritical
+	ErrorCode         string        `json:"error_code,omitempty"`          // Доменный код ошибки из внешнего каталога, см. WithErrorCode
+	ErrorType         string        `json:"error_type,omitempty"`          // Тип ошибки ("user"/"system"), см. IsUserError/IsSystemError
+	UserID            string        `json:"user_id,omitempty"`             // ID пользователя, связанного с ошибкой, см. WithUserID
+}
+
+// Frame - один кадр стека вызовов
+type Frame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"func"`
 }
 
+// ProductionMode управляет объемом информации, раскрываемой во внешних представлениях ошибки
+// (например, сериализация Cause только как текстового сообщения, без типа)
+var ProductionMode bool
+
+// CompatTraceFormat включает сериализацию Trace также в устаревшем плоском формате
+// (поле trace_legacy) для постепенной миграции потребителей на Frame
+var CompatTraceFormat bool
+
 // ErrorResponse представляет структуру ответа с ошибкой
 type ErrorResponse struct {
 	Success bool      `json:"success"`
 	Error   *AppError `json:"error"`
 }
 
+// Config управляет поведением Handler, возвращаемого NewHandler
+type Config struct {
+	ProductionMode     bool // Скрывает Trace и Details для ответов 5xx
+	StatusTextFallback bool // Подставляет http.StatusText, когда Message пуст
+}
+
+// ProblemDetails представляет ответ об ошибке в формате RFC 7807 (application/problem+json)
+type ProblemDetails struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Status    int       `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	Instance  string    `json:"instance,omitempty"`
+	Code      ErrorCode `json:"code,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Trace     []Frame   `json:"trace,omitempty"`
+}
+
 // SuccessResponse представляет структуру успешного ответа
 type SuccessResponse struct {
 	Success bool        `json:"success"`